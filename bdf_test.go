@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlaceBDFGlyph(t *testing.T) {
+	tests := []struct {
+		name                                    string
+		bitmap                                  []byte
+		bbxW, bbxH, bbxXoff, bbxYoff            int
+		dwidth, fontBBH, fontBBXoff, fontBBYoff int
+		wantCell                                []byte
+		wantW, wantH                            int
+	}{
+		{
+			name:     "glyph box matches font box exactly: no shift",
+			bitmap:   []byte{1, 0, 0, 1},
+			bbxW:     2,
+			bbxH:     2,
+			dwidth:   2,
+			fontBBH:  2,
+			wantCell: []byte{1, 0, 0, 1},
+			wantW:    2,
+			wantH:    2,
+		},
+		{
+			name:     "dwidth <= 0 falls back to the ink box's own width",
+			bitmap:   []byte{1, 1},
+			bbxW:     2,
+			bbxH:     1,
+			dwidth:   0,
+			fontBBH:  1,
+			wantCell: []byte{1, 1},
+			wantW:    2,
+			wantH:    1,
+		},
+		{
+			name:       "bearing offsets place ink within a taller/wider cell, clipping what falls outside",
+			bitmap:     []byte{1, 0, 0, 1},
+			bbxW:       2,
+			bbxH:       2,
+			bbxXoff:    1,
+			bbxYoff:    -1,
+			dwidth:     0,
+			fontBBH:    4,
+			fontBBXoff: 0,
+			fontBBYoff: -2,
+			wantCell:   []byte{0, 0, 0, 1, 0, 0, 0, 0},
+			wantW:      2,
+			wantH:      4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCell, gotW, gotH := placeBDFGlyph(tt.bitmap, tt.bbxW, tt.bbxH, tt.bbxXoff, tt.bbxYoff, tt.dwidth, tt.fontBBH, tt.fontBBXoff, tt.fontBBYoff)
+			if gotW != tt.wantW || gotH != tt.wantH {
+				t.Fatalf("placeBDFGlyph() size = (%d, %d), want (%d, %d)", gotW, gotH, tt.wantW, tt.wantH)
+			}
+			if !reflect.DeepEqual(gotCell, tt.wantCell) {
+				t.Errorf("placeBDFGlyph() cell = %v, want %v", gotCell, tt.wantCell)
+			}
+		})
+	}
+}
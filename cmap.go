@@ -0,0 +1,27 @@
+package main
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Cmap maps a rune to a glyphID in a Font's glyph storage, analogous to an
+// SFNT cmap subtable.
+type Cmap map[rune]glyphID
+
+// sjisBytesToRune decodes a Shift-JIS byte sequence (one byte for a
+// half-width glyph, two for a full-width one) to the rune it represents.
+// ok is false for byte sequences that don't decode to exactly one rune,
+// which happens for the unused cells of the embedded PNG atlases.
+func sjisBytesToRune(b ...byte) (rune, bool) {
+	s, err := japanese.ShiftJIS.NewDecoder().Bytes(b)
+	if err != nil {
+		return 0, false
+	}
+	r, size := utf8.DecodeRune(s)
+	if r == utf8.RuneError || size != len(s) {
+		return 0, false
+	}
+	return r, true
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// bakedGlyph is a single rasterized glyph awaiting code generation.
+type bakedGlyph struct {
+	r      rune
+	bitmap []byte
+	w, h   int
+}
+
+// readBDFGlyphs reads the glyphs for runes in wanted out of the BDF file at
+// path. It mirrors the parsing the runtime -bdf loader does, but is kept
+// self-contained here since bakefont doesn't import the main package.
+func readBDFGlyphs(path string, wanted map[rune]bool) ([]bakedGlyph, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	var (
+		glyphs                          []bakedGlyph
+		fontBBH, fontBBXoff, fontBBYoff int
+		encoding                        int
+		dwidth                          int
+		bbxW, bbxH, bbxXoff, bbxYoff    int
+		bitmap                          []byte
+		inBitmap                        bool
+		bitmapRow                       int
+	)
+
+	sc := bufio.NewScanner(fp)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "FONTBOUNDINGBOX":
+			if fontBBH, err = strconv.Atoi(fields[2]); err != nil {
+				return nil, fmt.Errorf("bad BDF FONTBOUNDINGBOX %q: %w", sc.Text(), err)
+			}
+			if fontBBXoff, err = strconv.Atoi(fields[3]); err != nil {
+				return nil, fmt.Errorf("bad BDF FONTBOUNDINGBOX %q: %w", sc.Text(), err)
+			}
+			if fontBBYoff, err = strconv.Atoi(fields[4]); err != nil {
+				return nil, fmt.Errorf("bad BDF FONTBOUNDINGBOX %q: %w", sc.Text(), err)
+			}
+		case "STARTCHAR":
+			encoding, dwidth, bbxW, bbxH, bbxXoff, bbxYoff, bitmap, inBitmap = -1, 0, 0, 0, 0, 0, nil, false
+		case "ENCODING":
+			encoding, err = strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("bad BDF ENCODING %q: %w", fields[1], err)
+			}
+		case "DWIDTH":
+			if dwidth, err = strconv.Atoi(fields[1]); err != nil {
+				return nil, fmt.Errorf("bad BDF DWIDTH %q: %w", sc.Text(), err)
+			}
+		case "BBX":
+			if bbxW, err = strconv.Atoi(fields[1]); err != nil {
+				return nil, fmt.Errorf("bad BDF BBX %q: %w", sc.Text(), err)
+			}
+			if bbxH, err = strconv.Atoi(fields[2]); err != nil {
+				return nil, fmt.Errorf("bad BDF BBX %q: %w", sc.Text(), err)
+			}
+			if bbxXoff, err = strconv.Atoi(fields[3]); err != nil {
+				return nil, fmt.Errorf("bad BDF BBX %q: %w", sc.Text(), err)
+			}
+			if bbxYoff, err = strconv.Atoi(fields[4]); err != nil {
+				return nil, fmt.Errorf("bad BDF BBX %q: %w", sc.Text(), err)
+			}
+			bitmap = make([]byte, bbxW*bbxH)
+		case "BITMAP":
+			inBitmap, bitmapRow = true, 0
+		case "ENDCHAR":
+			inBitmap = false
+			if encoding >= 0 && bbxW > 0 && bbxH > 0 && wanted[rune(encoding)] {
+				cell, cellW, cellH := placeBDFGlyph(bitmap, bbxW, bbxH, bbxXoff, bbxYoff, dwidth, fontBBH, fontBBXoff, fontBBYoff)
+				glyphs = append(glyphs, bakedGlyph{rune(encoding), cell, cellW, cellH})
+			}
+		default:
+			if !inBitmap {
+				continue
+			}
+			row, err := hex.DecodeString(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("bad BDF bitmap row %q: %w", fields[0], err)
+			}
+			if bitmapRow < bbxH {
+				for x := 0; x < bbxW; x++ {
+					if byteIdx := x / 8; byteIdx < len(row) && row[byteIdx]&(1<<(7-x%8)) != 0 {
+						bitmap[bitmapRow*bbxW+x] = 1
+					}
+				}
+			}
+			bitmapRow++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return glyphs, nil
+}
+
+// placeBDFGlyph lays a glyph's ink bitmap (bbxW x bbxH, positioned by its
+// BBX bearing offsets) into a cell sized to the font's advance width
+// (dwidth, falling back to the ink box's own width if DWIDTH was missing)
+// and the font's overall FONTBOUNDINGBOX height, mirroring the root
+// package's NewBDFFont so baked glyphs line up the same way glyphs loaded
+// live via -bdf do.
+func placeBDFGlyph(bitmap []byte, bbxW, bbxH, bbxXoff, bbxYoff, dwidth, fontBBH, fontBBXoff, fontBBYoff int) ([]byte, int, int) {
+	cellW := dwidth
+	if cellW <= 0 {
+		cellW = bbxW
+	}
+	cellH := fontBBH
+	if cellH <= 0 {
+		cellH = bbxH
+	}
+
+	dx := bbxXoff - fontBBXoff
+	dy := (fontBBYoff + fontBBH) - (bbxYoff + bbxH)
+
+	cell := make([]byte, cellW*cellH)
+	for y := 0; y < bbxH; y++ {
+		cy := y + dy
+		if cy < 0 || cy >= cellH {
+			continue
+		}
+		for x := 0; x < bbxW; x++ {
+			if bitmap[y*bbxW+x] == 0 {
+				continue
+			}
+			cx := x + dx
+			if cx < 0 || cx >= cellW {
+				continue
+			}
+			cell[cy*cellW+cx] = 1
+		}
+	}
+
+	return cell, cellW, cellH
+}
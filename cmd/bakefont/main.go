@@ -0,0 +1,98 @@
+// Command bakefont bakes a TTF/OTF or BDF font into a Go source file
+// declaring a ready-to-use *Font literal, so a term-banner build can embed
+// glyph data directly instead of parsing a font file at startup. It mirrors
+// the approach golang.org/x/image/font/basicfont's genbasicfont tool uses.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+)
+
+func main() {
+	var ttfPath = flag.String("ttf", "", "Path to a TrueType/OpenType font to bake")
+	var bdfPath = flag.String("bdf", "", "Path to a BDF bitmap font to bake, instead of -ttf")
+	var runesSpec = flag.String("runes", "U+0020-007E", "Comma-separated rune ranges, e.g. U+0020-007E,U+3040-309F")
+	var varName = flag.String("var", "BakedFont", "Name of the generated *Font variable")
+	var cellSize = flag.Int("cellsize", 8, "Glyph cell size in squares, used with -ttf")
+	var outPath = flag.String("o", "", "Output file (defaults to stdout)")
+	flag.Parse()
+
+	if (*ttfPath == "") == (*bdfPath == "") {
+		log.Fatal("exactly one of -ttf or -bdf is required")
+	}
+
+	runes, err := parseRuneRanges(*runesSpec)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	var glyphs []bakedGlyph
+	if *ttfPath != "" {
+		glyphs, err = readTTFGlyphs(*ttfPath, runes, *cellSize)
+	} else {
+		wanted := make(map[rune]bool, len(runes))
+		for _, r := range runes {
+			wanted[r] = true
+		}
+		glyphs, err = readBDFGlyphs(*bdfPath, wanted)
+	}
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i].r < glyphs[j].r })
+
+	out := os.Stdout
+	if *outPath != "" {
+		fp, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		defer fp.Close()
+		out = fp
+	}
+	w := bufio.NewWriter(out)
+	writeFontSource(w, *varName, glyphs)
+	if err := w.Flush(); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
+
+// writeFontSource emits a Go source file declaring var <varName> = &Font{...}.
+// It's meant to be dropped alongside the root package's main.go, reusing its
+// Font, Cmap and storedGlyph types and packing glyph bits in the same
+// row-major order parseGlyphsHW/parseGlyphsFW produce, so the renderer needs
+// no changes to consume it.
+func writeFontSource(w *bufio.Writer, varName string, glyphs []bakedGlyph) {
+	fmt.Fprintf(w, "// Code generated by bakefont; DO NOT EDIT.\n\n")
+	fmt.Fprintf(w, "package main\n\n")
+	fmt.Fprintf(w, "var %s = &Font{\n", varName)
+	fmt.Fprintf(w, "\tcmap: Cmap{\n")
+	for id, g := range glyphs {
+		fmt.Fprintf(w, "\t\t%s: %d,\n", strconv.QuoteRune(g.r), id)
+	}
+	fmt.Fprintf(w, "\t},\n")
+	fmt.Fprintf(w, "\tglyphs: []storedGlyph{\n")
+	for _, g := range glyphs {
+		fmt.Fprintf(w, "\t\t{bitmap: %s, w: %d, h: %d}, // %s\n", byteSliceLiteral(g.bitmap), g.w, g.h, strconv.QuoteRune(g.r))
+	}
+	fmt.Fprintf(w, "\t},\n")
+	fmt.Fprintf(w, "}\n")
+}
+
+func byteSliceLiteral(bitmap []byte) string {
+	s := "[]byte{"
+	for i, b := range bitmap {
+		if i > 0 {
+			s += ", "
+		}
+		s += strconv.Itoa(int(b))
+	}
+	return s + "}"
+}
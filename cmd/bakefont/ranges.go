@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseRuneRanges parses a comma-separated list of Unicode range specs like
+// "U+0020-007E,U+3040-309F" (a bare "U+XXXX" is a single-rune range) into
+// the sorted, deduplicated list of runes it covers.
+func parseRuneRanges(spec string) ([]rune, error) {
+	seen := map[rune]bool{}
+	var runes []rune
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		lo, hi, found := strings.Cut(part, "-")
+		loR, err := parseCodepoint(lo)
+		if err != nil {
+			return nil, err
+		}
+		hiR := loR
+		if found {
+			hiR, err = parseCodepoint(hi)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if hiR < loR {
+			return nil, fmt.Errorf("invalid range %q: end before start", part)
+		}
+
+		for r := loR; r <= hiR; r++ {
+			if !seen[r] {
+				seen[r] = true
+				runes = append(runes, r)
+			}
+		}
+	}
+
+	return runes, nil
+}
+
+func parseCodepoint(s string) (rune, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "U+")
+	v, err := strconv.ParseInt(s, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid codepoint %q: %w", s, err)
+	}
+	return rune(v), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRuneRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []rune
+		wantErr bool
+	}{
+		{
+			name: "single codepoint",
+			spec: "U+0041",
+			want: []rune{'A'},
+		},
+		{
+			name: "range",
+			spec: "U+0041-0043",
+			want: []rune{'A', 'B', 'C'},
+		},
+		{
+			name: "multiple comma-separated parts",
+			spec: "U+0041,U+0043-0044",
+			want: []rune{'A', 'C', 'D'},
+		},
+		{
+			name: "overlapping ranges deduplicate and keep first-seen order",
+			spec: "U+0041-0043,U+0042-0044",
+			want: []rune{'A', 'B', 'C', 'D'},
+		},
+		{
+			name: "whitespace around parts and codepoints is ignored",
+			spec: " U+0041 - 0042 , U+0044 ",
+			want: []rune{'A', 'B', 'D'},
+		},
+		{
+			name: "blank parts are skipped",
+			spec: "U+0041,,U+0042",
+			want: []rune{'A', 'B'},
+		},
+		{
+			name:    "end before start is an error",
+			spec:    "U+0043-0041",
+			wantErr: true,
+		},
+		{
+			name:    "bad codepoint is an error",
+			spec:    "U+ZZZZ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRuneRanges(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRuneRanges(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRuneRanges(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// readTTFGlyphs rasterizes each rune in runes from the TTF/OTF font at path
+// into a cellSize x cellSize bitmap, the same way the runtime -ttf backend
+// (TTFFont, in the root package) does, skipping runes the font has no
+// glyph for.
+func readTTFGlyphs(path string, runes []rune, cellSize int) ([]bakedGlyph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    float64(cellSize),
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var glyphs []bakedGlyph
+	for _, r := range runes {
+		dr, mask, maskp, _, ok := face.Glyph(fixed.P(0, cellSize), r)
+		if !ok {
+			continue
+		}
+
+		bitmap := make([]byte, cellSize*cellSize)
+		for dy := 0; dy < dr.Dy(); dy++ {
+			cy := dr.Min.Y + dy
+			if cy < 0 || cy >= cellSize {
+				continue
+			}
+			for dx := 0; dx < dr.Dx(); dx++ {
+				cx := dr.Min.X + dx
+				if cx < 0 || cx >= cellSize {
+					continue
+				}
+				_, _, _, a := mask.At(maskp.X+dx, maskp.Y+dy).RGBA()
+				if a > 0x7fff {
+					bitmap[cy*cellSize+cx] = 1
+				}
+			}
+		}
+		glyphs = append(glyphs, bakedGlyph{r, bitmap, cellSize, cellSize})
+	}
+
+	return glyphs, nil
+}
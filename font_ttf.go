@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// TTFFont rasterizes glyphs from a TrueType/OpenType font on demand and
+// caches the result, so unlike Font it isn't limited to the runes covered
+// by the embedded Shift-JIS bitmap atlases.
+type TTFFont struct {
+	face     font.Face
+	cellSize int
+	cache    map[rune][]byte
+}
+
+// NewTTFFont parses the font at path and prepares it to rasterize glyphs
+// into cellSize x cellSize bitmaps.
+func NewTTFFont(path string, cellSize int) (*TTFFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    float64(cellSize),
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TTFFont{face: face, cellSize: cellSize, cache: map[rune][]byte{}}, nil
+}
+
+// Glyph implements FontProvider by rasterizing r to a 1-bit bitmap,
+// thresholding the glyph's alpha mask, and caching the result for reuse.
+func (f *TTFFont) Glyph(r rune) ([]byte, int, int, bool) {
+	if bitmap, ok := f.cache[r]; ok {
+		if bitmap == nil {
+			return nil, 0, 0, false
+		}
+		return bitmap, f.cellSize, f.cellSize, true
+	}
+
+	dr, mask, maskp, _, ok := f.face.Glyph(fixed.P(0, f.cellSize), r)
+	if !ok {
+		f.cache[r] = nil
+		return nil, 0, 0, false
+	}
+
+	bitmap := make([]byte, f.cellSize*f.cellSize)
+	for dy := 0; dy < dr.Dy(); dy++ {
+		cy := dr.Min.Y + dy
+		if cy < 0 || cy >= f.cellSize {
+			continue
+		}
+		for dx := 0; dx < dr.Dx(); dx++ {
+			cx := dr.Min.X + dx
+			if cx < 0 || cx >= f.cellSize {
+				continue
+			}
+			_, _, _, a := mask.At(maskp.X+dx, maskp.Y+dy).RGBA()
+			if a > 0x7fff {
+				bitmap[cy*f.cellSize+cx] = 1
+			}
+		}
+	}
+	f.cache[r] = bitmap
+	return bitmap, f.cellSize, f.cellSize, true
+}
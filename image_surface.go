@@ -0,0 +1,74 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+)
+
+// imageSurface paints squares onto an in-memory RGBA image instead of a
+// terminal screen, so the banner pipeline can be used to render a PNG.
+type imageSurface struct {
+	img *image.RGBA
+}
+
+func newImageSurface(width, height int, bg color.Color) *imageSurface {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: bg}, image.Point{}, draw.Src)
+	return &imageSurface{img: img}
+}
+
+func (s *imageSurface) SetContent(x, y int, style Style) {
+	s.img.Set(x, y, style.Fg)
+}
+
+// calcImageCanvasSize computes the per-line x/y offsets and overall grid
+// dimensions for rendering banner to a fixed-size image, without querying
+// a terminal for its size the way calcSquareSizeAndOffset does.
+func calcImageCanvasSize(banner Banner, font FontProvider) (gridWidthMax, gridHeight int, xOffsets, yOffsets []int) {
+	gridWidths := make([]int, len(banner))
+	lineHeights := make([]int, len(banner))
+	for i, line := range banner {
+		gridWidths[i], lineHeights[i] = calcLineDims(font, line)
+		if gridWidthMax < gridWidths[i] {
+			gridWidthMax = gridWidths[i]
+		}
+		gridHeight += lineHeights[i]
+	}
+
+	xOffsets = make([]int, len(banner))
+	for i, gridWidth := range gridWidths {
+		xOffsets[i] = (gridWidthMax - gridWidth) / 2
+	}
+	yOffsets = make([]int, len(banner))
+	y := 0
+	for i, lineHeight := range lineHeights {
+		yOffsets[i] = y
+		y += lineHeight
+	}
+
+	return gridWidthMax, gridHeight, xOffsets, yOffsets
+}
+
+// renderToImage renders banner to an RGBA image sized to fit its grid
+// exactly and writes it to path as a PNG.
+func renderToImage(path string, banner Banner, font FontProvider, bg, fg color.Color) {
+	gridWidth, gridHeight, xOffsets, yOffsets := calcImageCanvasSize(banner, font)
+	squareW, squareH := glyphWidthFW, glyphHeightFW
+
+	s := newImageSurface(gridWidth*squareW, gridHeight*squareH, bg)
+	drawBanner(s, RenderParams{Style{Fg: fg}, squareW, squareH}, banner, font, xOffsets, yOffsets)
+
+	fp, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	defer fp.Close()
+
+	if err := png.Encode(fp, s.img); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
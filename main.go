@@ -3,12 +3,11 @@ package main
 import (
 	"embed"
 	"flag"
+	"image/color"
 	"image/png"
 	"log"
 
 	"github.com/gdamore/tcell/v2"
-	"golang.org/x/text/encoding"
-	"golang.org/x/text/encoding/japanese"
 )
 
 const (
@@ -25,10 +24,10 @@ const (
 	// Glyph height of full-width characters.
 	glyphHeightFW = 8
 
+	// First byte of a Shift-JIS full-width lead byte, first block.
 	fw1FirstByteStart = 0x81
-	fw1FirstByteEnd   = 0x9F
+	// First byte of a Shift-JIS full-width lead byte, second block.
 	fw2FirstByteStart = 0xE0
-	fw2FirstByteEnd   = 0xEF
 	fwSecondByteStart = 0x40
 	fwSecondByteEnd   = 0x9F
 )
@@ -42,73 +41,91 @@ type GlyphHW uint32
 // One glyph for full-width characters.
 type GlyphFW uint64
 
-type Font struct {
-	// Half-width glyphs. It is keyed by a raw character code.
-	glyphsHW *[256]GlyphHW
-	// Full-width glyphs.
-	glyphsFW1 *[31][189]GlyphFW
-	// Full-width glyphs.
-	glyphsFW2 *[16][189]GlyphFW
+// FontProvider supplies the glyph bitmap for a single rune. bitmap is a
+// row-major grid of w*h cells where a non-zero cell means "draw a square
+// here"; ok is false when the font has no glyph for r.
+type FontProvider interface {
+	Glyph(r rune) (bitmap []byte, w, h int, ok bool)
 }
 
-type CharClass uint8
+// storedGlyph is one glyph in a Font's storage, addressed by a glyphID
+// rather than by the Shift-JIS byte pair that used to select it.
+type storedGlyph struct {
+	bitmap []byte
+	w, h   int
+}
 
-const (
-	charClassHW = iota
-	charClassFW1
-	charClassFW2
-)
+type glyphID int
 
-// Get character class.
-func getCharClass(b byte) CharClass {
-	if fw1FirstByteStart <= b && b <= fw1FirstByteEnd {
-		return charClassFW1
-	} else if fw2FirstByteStart <= b && b <= fw2FirstByteEnd {
-		return charClassFW2
-	} else {
-		return charClassHW
-	}
+// Font is a bitmap font backed by a Cmap from runes to storedGlyph indices,
+// so its glyph storage no longer has to be keyed by Shift-JIS byte pairs.
+type Font struct {
+	cmap   Cmap
+	glyphs []storedGlyph
 }
 
-func glyphHWToglyphFW(gHW GlyphHW) GlyphFW {
-	gFW := GlyphFW(0)
+func glyphHWBitmap(g GlyphHW) []byte {
+	bitmap := make([]byte, bitsHW)
 	for i := 0; i < bitsHW; i++ {
-		if gHW&(1<<i) != 0 {
-			j := i/4*8 + i%4
-			gFW |= 1 << j
+		if g&(1<<i) != 0 {
+			bitmap[i] = 1
 		}
 	}
-	return gFW
+	return bitmap
 }
 
-func utf8ToShiftJISReplacingUnsupported(in string) (string, error) {
-	e := encoding.ReplaceUnsupported(japanese.ShiftJIS.NewEncoder())
-	return e.String(in)
+func glyphFWBitmap(g GlyphFW) []byte {
+	bitmap := make([]byte, bitsFW)
+	for i := 0; i < bitsFW; i++ {
+		if g&(1<<i) != 0 {
+			bitmap[i] = 1
+		}
+	}
+	return bitmap
 }
 
-type Banner []string
+// tofuBitmap is drawn in place of a glyph missing from a Font's cmap, the
+// hollow box other renderers use for unsupported codepoints.
+var tofuBitmap = makeTofuBitmap(glyphWidthFW, glyphHeightFW)
 
-func NewBanner(lines []string) (Banner, error) {
-	b := make(Banner, len(lines))
-	for i, line := range lines {
-		lineShiftJIS, err := utf8ToShiftJISReplacingUnsupported(line)
-		if err != nil {
-			return nil, err
+func makeTofuBitmap(w, h int) []byte {
+	bitmap := make([]byte, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x == 0 || x == w-1 || y == 0 || y == h-1 {
+				bitmap[y*w+x] = 1
+			}
 		}
-		b[i] = lineShiftJIS
 	}
-	return b, nil
+	return bitmap
+}
+
+// Glyph implements FontProvider via f.cmap, falling back to tofuBitmap for
+// runes the font has no glyph for.
+func (f *Font) Glyph(r rune) ([]byte, int, int, bool) {
+	id, ok := f.cmap[r]
+	if !ok {
+		return tofuBitmap, glyphWidthFW, glyphHeightFW, true
+	}
+	g := f.glyphs[id]
+	return g.bitmap, g.w, g.h, true
+}
+
+type Banner []string
+
+func NewBanner(lines []string) Banner {
+	return Banner(lines)
 }
 
 type Renderer struct {
 	scr          tcell.Screen
 	squareWidth  int
 	squareHeight int
-	bgStyle      tcell.Style
-	fgStyle      tcell.Style
+	bg           color.Color
+	fg           color.Color
 }
 
-func NewRenderer(bgStyle, fgStyle tcell.Style) (*Renderer, error) {
+func NewRenderer(bg, fg color.Color) (*Renderer, error) {
 	scr, err := tcell.NewScreen()
 	if err != nil {
 		return nil, err
@@ -118,10 +135,20 @@ func NewRenderer(bgStyle, fgStyle tcell.Style) (*Renderer, error) {
 		return nil, err
 	}
 
-	r := &Renderer{scr, 0, 0, bgStyle, fgStyle}
+	r := &Renderer{scr, 0, 0, bg, fg}
 	return r, nil
 }
 
+// Surface returns a Surface that paints onto this renderer's screen.
+func (r *Renderer) Surface() Surface {
+	return tcellSurface{r.scr}
+}
+
+// FgStyle is the Style used to paint banner squares onto r.Surface().
+func (r *Renderer) FgStyle() Style {
+	return Style{Fg: r.fg}
+}
+
 func (r *Renderer) ScreenSize() (int, int) {
 	return r.scr.Size()
 }
@@ -148,59 +175,83 @@ func (r *Renderer) Sync() {
 }
 
 func (r *Renderer) ClearScreen() {
-	r.scr.SetStyle(r.bgStyle)
+	cr, cg, cb, _ := r.bg.RGBA()
+	r.scr.SetStyle(tcell.StyleDefault.Background(tcell.NewRGBColor(int32(cr>>8), int32(cg>>8), int32(cb>>8))))
 	r.scr.Clear()
 }
 
-func (r *Renderer) DrawSquare(sx, sy int) {
-	w, h := r.squareWidth, r.squareHeight
-	for dx := 0; dx < w; dx++ {
-		for dy := 0; dy < h; dy++ {
-			r.scr.SetContent(sx*w+dx, sy*h+dy, ' ', nil, r.fgStyle)
+// RenderParams bundles the geometry and color DrawSquare/drawGlyph need to
+// paint onto a Surface.
+type RenderParams struct {
+	Style   Style
+	SquareW int
+	SquareH int
+}
+
+func DrawSquare(s Surface, p RenderParams, sx, sy int) {
+	for dx := 0; dx < p.SquareW; dx++ {
+		for dy := 0; dy < p.SquareH; dy++ {
+			s.SetContent(sx*p.SquareW+dx, sy*p.SquareH+dy, p.Style)
 		}
 	}
 }
 
-func drawGlyph(r *Renderer, g GlyphFW, sx, sy int) {
-	for i := 0; i < bitsFW; i++ {
-		filled := g&(1<<i) != 0
-		if !filled {
-			continue
+func drawGlyph(s Surface, p RenderParams, bitmap []byte, w, h, sx, sy int) {
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w; dx++ {
+			if bitmap[dy*w+dx] == 0 {
+				continue
+			}
+			DrawSquare(s, p, sx+dx, sy+dy)
 		}
-		dx := i % glyphWidthFW
-		dy := i / glyphWidthFW
-		r.DrawSquare(sx+dx, sy+dy)
 	}
 }
 
-func calcGridWidth(s string) int {
-	w := 0
-	for i := 0; i < len(s); i++ {
-		switch getCharClass(s[i]) {
-		case charClassHW:
-			w += 1
-		case charClassFW1, charClassFW2:
-			i++
-			w += 2
+// calcLineDims sums s's glyph widths and finds its tallest glyph, falling
+// back to the half-width glyph's dimensions for runes font has no glyph
+// for. Tracking height per line (rather than assuming every glyph is
+// glyphHeightFW tall) lets the grid fit fonts with taller cells, such as a
+// BDF loaded with -bdf or a TTF rasterized at a -cellsize other than
+// glyphHeightFW.
+func calcLineDims(font FontProvider, s string) (width, height int) {
+	for _, r := range s {
+		_, gw, gh, ok := font.Glyph(r)
+		if !ok {
+			gw, gh = glyphWidthHW, glyphHeightHW
+		}
+		width += gw
+		if height < gh {
+			height = gh
 		}
 	}
+	if height == 0 {
+		height = glyphHeightFW
+	}
 
-	return w * glyphWidthHW
+	return width, height
 }
 
-func calcSquareSizeAndOffset(r *Renderer, banner Banner) (int, int, []int, int) {
+func calcSquareSizeAndOffset(r *Renderer, banner Banner, font FontProvider) (int, int, []int, []int) {
 	scrW, scrH := r.ScreenSize()
 
 	gridWidthMax := 0
 	gridWidths := make([]int, len(banner))
+	lineHeights := make([]int, len(banner))
+	gridHeight := 0
 	for i, line := range banner {
-		gridWidths[i] = calcGridWidth(line)
+		gridWidths[i], lineHeights[i] = calcLineDims(font, line)
 		if gridWidthMax < gridWidths[i] {
 			gridWidthMax = gridWidths[i]
 		}
+		gridHeight += lineHeights[i]
 	}
-	gridHeight := glyphHeightFW * len(banner)
 
+	if gridWidthMax == 0 {
+		gridWidthMax = 1
+	}
+	if gridHeight == 0 {
+		gridHeight = 1
+	}
 	squareW := scrW / gridWidthMax
 	squareH := scrH / gridHeight
 	if squareW > squareH*8 {
@@ -214,57 +265,60 @@ func calcSquareSizeAndOffset(r *Renderer, banner Banner) (int, int, []int, int)
 	for i, gridWidth := range gridWidths {
 		xOffsets[i] = (scrW/squareW - gridWidth) / 2
 	}
-	yOffset := (scrH/squareH - gridHeight) / 2
+	yMargin := (scrH/squareH - gridHeight) / 2
+	yOffsets := make([]int, len(banner))
+	y := yMargin
+	for i, lineHeight := range lineHeights {
+		yOffsets[i] = y
+		y += lineHeight
+	}
 
-	return squareW, squareH, xOffsets, yOffset
+	return squareW, squareH, xOffsets, yOffsets
 }
 
-func drawOneLine(r *Renderer, s string, xOffset, yOffset int, font *Font) {
-	for i := 0; i < len(s); i++ {
-		b := s[i]
-		x := xOffset + i*glyphWidthHW
-		y := yOffset
-		var g GlyphFW
-		switch getCharClass(b) {
-		case charClassHW:
-			g = glyphHWToglyphFW(font.glyphsHW[b])
-		case charClassFW1:
-			b2 := s[i+1]
-			g = font.glyphsFW1[b-fw1FirstByteStart][b2-fwSecondByteStart]
-			i++
-		case charClassFW2:
-			b2 := s[i+1]
-			g = font.glyphsFW1[b-fw2FirstByteStart][b2-fwSecondByteStart]
-			i++
+func drawOneLine(s Surface, p RenderParams, line string, xOffset, yOffset int, font FontProvider) {
+	x := xOffset
+	for _, rn := range line {
+		bitmap, w, h, ok := font.Glyph(rn)
+		if !ok {
+			x += glyphWidthHW
+			continue
 		}
-		drawGlyph(r, g, x, y)
+		drawGlyph(s, p, bitmap, w, h, x, yOffset)
+		x += w
 	}
 }
 
-func drawBanner(r *Renderer, banner Banner, font *Font) {
-	r.ClearScreen()
-
-	sw, sh, xOffsets, yOffset := calcSquareSizeAndOffset(r, banner)
-	r.SetSquareSize(sw, sh)
-
+func drawBanner(s Surface, p RenderParams, banner Banner, font FontProvider, xOffsets, yOffsets []int) {
 	for i, line := range banner {
-		drawOneLine(r, line, xOffsets[i], yOffset+i*glyphHeightFW, font)
+		drawOneLine(s, p, line, xOffsets[i], yOffsets[i], font)
+	}
+}
+
+// addGlyph appends bitmap to font's glyph storage and, if r decodes to a
+// real rune, maps r to it in font's cmap.
+func addGlyph(font *Font, r rune, ok bool, bitmap []byte, w, h int) {
+	id := glyphID(len(font.glyphs))
+	font.glyphs = append(font.glyphs, storedGlyph{bitmap, w, h})
+	if ok {
+		font.cmap[r] = id
 	}
 }
 
-func parseGlyphsHW(filePath string) (*[256]GlyphHW, error) {
+// parseGlyphsHW reads the half-width glyph atlas at filePath, keyed by
+// Shift-JIS single bytes, and adds each glyph to font.
+func parseGlyphsHW(font *Font, filePath string) error {
 	fp, err := fontFiles.Open(filePath)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer fp.Close()
 
 	img, err := png.Decode(fp)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	gs := [256]GlyphHW{}
 	for dy := 0; dy < 16; dy++ {
 		for dx := 0; dx < 16; dx++ {
 			glyph := GlyphHW(0)
@@ -276,115 +330,140 @@ func parseGlyphsHW(filePath string) (*[256]GlyphHW, error) {
 					glyph |= 1 << i
 				}
 			}
-			c := dy*16 + dx
-			gs[c] = glyph
+			b := byte(dy*16 + dx)
+			rn, ok := sjisBytesToRune(b)
+			addGlyph(font, rn, ok, glyphHWBitmap(glyph), glyphWidthHW, glyphHeightHW)
 		}
 	}
-	return &gs, nil
+	return nil
 }
 
-func parseGlyphsFW(filePath string) (*[31][189]GlyphFW, *[16][189]GlyphFW, error) {
+// parseGlyphsFW reads the full-width glyph atlas at filePath, keyed by
+// Shift-JIS lead/trail byte pairs, and adds each glyph to font.
+func parseGlyphsFW(font *Font, filePath string) error {
 	fp, err := fontFiles.Open(filePath)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 	defer fp.Close()
 
 	img, err := png.Decode(fp)
 	if err != nil {
-		return nil, nil, err
-	}
-
-	gs1 := [31][189]GlyphFW{}
-	for dy := 0; dy < 62; dy++ {
-		for dx := 0; dx < 94; dx++ {
-			glyph := GlyphFW(0)
-			for i := 0; i < bitsFW; i++ {
-				x := dx*glyphWidthFW + i%glyphWidthFW
-				y := dy*glyphHeightFW + i/glyphWidthFW
-				r, g, b, _ := img.At(x, y).RGBA()
-				if r == 0 && b == 0 && g == 0 {
-					glyph |= 1 << i
-				}
-			}
-			c1 := dy / 2
-			c2 := dx + (fwSecondByteEnd-fwSecondByteStart)*(dy%2)
-			gs1[c1][c2] = glyph
-		}
+		return err
 	}
 
-	yOffset := 31 * glyphHeightFW
-	gs2 := [16][189]GlyphFW{}
-	for dy := 0; dy < 16; dy++ {
-		for dx := 0; dx < 94; dx++ {
-			glyph := GlyphFW(0)
-			for i := 0; i < bitsFW; i++ {
-				x := dx*glyphWidthFW + i%glyphWidthFW
-				y := dy*glyphHeightFW + i/glyphWidthFW + yOffset
-				r, g, b, _ := img.At(x, y).RGBA()
-				if r == 0 && b == 0 && g == 0 {
-					glyph |= 1 << i
+	parseBlock := func(firstByteStart byte, dyCount, yOffset int) {
+		for dy := 0; dy < dyCount; dy++ {
+			for dx := 0; dx < 94; dx++ {
+				glyph := GlyphFW(0)
+				for i := 0; i < bitsFW; i++ {
+					x := dx*glyphWidthFW + i%glyphWidthFW
+					y := dy*glyphHeightFW + i/glyphWidthFW + yOffset
+					r, g, b, _ := img.At(x, y).RGBA()
+					if r == 0 && b == 0 && g == 0 {
+						glyph |= 1 << i
+					}
 				}
+				b1 := firstByteStart + byte(dy/2)
+				b2 := fwSecondByteStart + byte(dx+(fwSecondByteEnd-fwSecondByteStart)*(dy%2))
+				rn, ok := sjisBytesToRune(b1, b2)
+				addGlyph(font, rn, ok, glyphFWBitmap(glyph), glyphWidthFW, glyphHeightFW)
 			}
-			c1 := dy / 2
-			c2 := dx + (fwSecondByteEnd-fwSecondByteStart)*(dy%2)
-			gs2[c1][c2] = glyph
 		}
 	}
 
-	return &gs1, &gs2, nil
+	parseBlock(fw1FirstByteStart, 62, 0)
+	parseBlock(fw2FirstByteStart, 16, 31*glyphHeightFW)
+	return nil
 }
 
 func prepareFont(fileHW, fileFW string) (*Font, error) {
-	glyphsHW, err := parseGlyphsHW(fileHW)
-	if err != nil {
+	font := &Font{cmap: Cmap{}}
+	if err := parseGlyphsHW(font, fileHW); err != nil {
 		return nil, err
 	}
-	glyphsFW1, glyphsFW2, err := parseGlyphsFW(fileFW)
-	if err != nil {
+	if err := parseGlyphsFW(font, fileFW); err != nil {
 		return nil, err
 	}
-	return &Font{glyphsHW, glyphsFW1, glyphsFW2}, nil
+	return font, nil
 }
 
 func main() {
 	var fontType = flag.String("f", "mincho", "Font (mincho or gothic)")
+	var ttfPath = flag.String("ttf", "", "Path to a TrueType/OpenType font file, used instead of -f")
+	var cellSize = flag.Int("cellsize", glyphWidthFW, "Glyph cell size in squares, used with -ttf")
+	var bdfPath = flag.String("bdf", "", "Path to a BDF bitmap font file, used instead of -f/-ttf")
+	var outPath = flag.String("o", "", "Render to this PNG file instead of the terminal")
+	var bgHex = flag.String("bg", "#000000", "Background color in #rrggbb form")
+	var fgHex = flag.String("fg", "#808000", "Foreground (square) color in #rrggbb form")
 	flag.Parse()
-	var fontFileHW string
-	var fontFileFW string
-	if *fontType == "mincho" {
-		fontFileHW = "assets/misaki_gothic_2nd_4x8.png"
-		fontFileFW = "assets/misaki_mincho.png"
-	} else if *fontType == "gothic" {
-		fontFileHW = "assets/misaki_gothic_2nd_4x8.png"
-		fontFileFW = "assets/misaki_gothic_2nd.png"
-	} else {
-		log.Fatalf("Unknown font: %s", *fontType)
-	}
 
 	if flag.NArg() == 0 {
 		return
 	}
 
-	font, err := prepareFont(fontFileHW, fontFileFW)
+	bg, err := parseHexColor(*bgHex)
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
-
-	r, err := NewRenderer(
-		tcell.StyleDefault.Background(tcell.ColorReset).Foreground(tcell.ColorReset),
-		tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorOlive),
-	)
+	fg, err := parseHexColor(*fgHex)
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
-	defer r.Fini()
 
-	banner, err := NewBanner(flag.Args())
+	var font FontProvider
+	if *ttfPath != "" {
+		f, err := NewTTFFont(*ttfPath, *cellSize)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		font = f
+	} else if *bdfPath != "" {
+		f, err := NewBDFFont(*bdfPath)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		font = f
+	} else {
+		var fontFileHW string
+		var fontFileFW string
+		if *fontType == "mincho" {
+			fontFileHW = "assets/misaki_gothic_2nd_4x8.png"
+			fontFileFW = "assets/misaki_mincho.png"
+		} else if *fontType == "gothic" {
+			fontFileHW = "assets/misaki_gothic_2nd_4x8.png"
+			fontFileFW = "assets/misaki_gothic_2nd.png"
+		} else {
+			log.Fatalf("Unknown font: %s", *fontType)
+		}
+
+		f, err := prepareFont(fontFileHW, fontFileFW)
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		font = f
+	}
+
+	banner := NewBanner(flag.Args())
+
+	if *outPath != "" {
+		renderToImage(*outPath, banner, font, bg, fg)
+		return
+	}
+
+	r, err := NewRenderer(bg, fg)
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
-	drawBanner(r, banner, font)
+	defer r.Fini()
+
+	draw := func() {
+		r.ClearScreen()
+		sw, sh, xOffsets, yOffsets := calcSquareSizeAndOffset(r, banner, font)
+		r.SetSquareSize(sw, sh)
+		drawBanner(r.Surface(), RenderParams{r.FgStyle(), sw, sh}, banner, font, xOffsets, yOffsets)
+	}
+	draw()
 
 	for {
 		r.Show()
@@ -392,7 +471,7 @@ func main() {
 		ev := r.PollEvent()
 		switch ev := ev.(type) {
 		case *tcell.EventResize:
-			drawBanner(r, banner, font)
+			draw()
 			r.Sync()
 		case *tcell.EventKey:
 			if ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC || ev.Rune() == 'q' {
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Style is the color a Surface should paint a square with. It's a minimal
+// abstraction over tcell.Style so non-terminal Surface implementations
+// (such as imageSurface) don't need to depend on tcell.
+type Style struct {
+	Fg color.Color
+}
+
+// Surface is a drawing target for a single grid cell. DrawSquare, drawGlyph,
+// drawOneLine and drawBanner are written against this interface so the same
+// banner pipeline can paint a terminal screen or an in-memory image.
+type Surface interface {
+	SetContent(x, y int, style Style)
+}
+
+// tcellSurface adapts a tcell.Screen to Surface.
+type tcellSurface struct {
+	scr tcell.Screen
+}
+
+func (s tcellSurface) SetContent(x, y int, style Style) {
+	r, g, b, _ := style.Fg.RGBA()
+	tStyle := tcell.StyleDefault.Background(tcell.NewRGBColor(int32(r>>8), int32(g>>8), int32(b>>8)))
+	s.scr.SetContent(x, y, ' ', nil, tStyle)
+}
+
+// parseHexColor parses a "#rrggbb" string into an opaque color.RGBA.
+func parseHexColor(s string) (color.RGBA, error) {
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: %w", s, err)
+	}
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}, nil
+}